@@ -5,10 +5,13 @@
 package gohelper
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 )
 
 type Error string
@@ -17,9 +20,93 @@ func (e Error) Error() string { return string(e) }
 
 const notValidPlatform = Error("No valid platform found.")
 
-type envList map[string]string
+// Provider identifies which hosting provider's runtime environment a
+// PlatformConfig was built from.
+const (
+	ProviderPlatformsh = "platformsh"
+	ProviderUpsun      = "upsun"
+)
+
+// ErrNotInRuntime is returned by accessors that depend on data that is only
+// available once the application is deployed, when they are called while
+// InBuild() is true.
+const ErrNotInRuntime = Error("This data is not available during the build phase.")
+
+// Sentinel errors returned while decoding a Platform.sh environment
+// variable, so callers can tell "not set" apart from "malformed" with
+// errors.Is instead of parsing error strings.
+const (
+	ErrVarNotSet    = Error("environment variable not set")
+	ErrBase64Decode = Error("failed to base64-decode environment variable")
+	ErrJSONDecode   = Error("failed to JSON-decode environment variable")
+)
+
+// envVar is a single name/value pair from PLATFORM_VARIABLES.
+type envVar struct {
+	Name  string
+	Value string
+}
+
+// envList holds the decoded PLATFORM_VARIABLES in insertion order. It's a
+// slice rather than a map so that Variables() iterates deterministically.
+type envList []envVar
+
+// get looks up a variable by name.
+func (e envList) get(name string) (string, bool) {
+	for _, v := range e {
+		if v.Name == name {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
 
-type envReader func(string) string
+// UnmarshalJSON decodes a JSON object into an envList, preserving the
+// original key order.
+func (e *envList) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("Expected a JSON object for the variables list.")
+	}
+
+	var result envList
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return fmt.Errorf("Expected a string key in the variables list.")
+		}
+
+		var value string
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+
+		result = append(result, envVar{Name: key, Value: value})
+	}
+
+	*e = result
+	return nil
+}
+
+// envReader mirrors os.LookupEnv so callers can tell "unset" apart from
+// "set to an empty string".
+type envReader func(string) (string, bool)
+
+// lookupOrEmpty reads a variable, treating "unset" the same as "empty
+// string" for the many simple fields where the distinction doesn't matter.
+func lookupOrEmpty(getter envReader, name string) string {
+	val, _ := getter(name)
+	return val
+}
 
 type Relationship struct {
 	Host     string `json:"host"`
@@ -35,9 +122,127 @@ type Relationship struct {
 }
 type Relationships map[string][]Relationship
 
+// RouteCache describes the cache settings for a single route, as found in
+// PLATFORM_ROUTES.
+type RouteCache struct {
+	Enabled    bool     `json:"enabled"`
+	DefaultTtl int      `json:"default_ttl"`
+	Cookies    []string `json:"cookies"`
+	Headers    []string `json:"headers"`
+}
+
+// RouteSsi describes the SSI (server side include) settings for a route.
+type RouteSsi struct {
+	Enabled bool `json:"enabled"`
+}
+
+// RouteRestrictions describes the access restrictions applied to a route.
+type RouteRestrictions struct {
+	XFrameOptions string `json:"x_frame_options"`
+	SharedCookies bool   `json:"shared_cookies"`
+}
+
+// Route describes a single entry from PLATFORM_ROUTES, keyed by URL.
+type Route struct {
+	Primary       bool              `json:"primary"`
+	Id            string            `json:"id"`
+	ProductionUrl string            `json:"production_url"`
+	Attributes    map[string]string `json:"attributes"`
+	Type          string            `json:"type"`
+	Upstream      string            `json:"upstream"`
+	To            string            `json:"to"`
+	OriginalUrl   string            `json:"original_url"`
+	Cache         RouteCache        `json:"cache"`
+	Ssi           RouteSsi          `json:"ssi"`
+	Restrictions  RouteRestrictions `json:"restrictions"`
+}
+
+// Routes maps a route URL to its Route definition.
+type Routes map[string]Route
+
+// Mount describes a single writable mount point declared by an application.
+type Mount struct {
+	Source     string `json:"source"`
+	SourcePath string `json:"source_path"`
+}
+
+// Cron describes a single scheduled task declared by an application.
+type Cron struct {
+	Spec string `json:"spec"`
+	Cmd  string `json:"cmd"`
+}
+
+// Hooks describes the build/deploy lifecycle commands declared by an
+// application.
+type Hooks struct {
+	Build      string `json:"build"`
+	Deploy     string `json:"deploy"`
+	PostDeploy string `json:"post_deploy"`
+}
+
+// Location describes the web server configuration for a single URL path.
+type Location struct {
+	Root     string            `json:"root"`
+	Passthru bool              `json:"passthru"`
+	Scripts  bool              `json:"scripts"`
+	Allow    bool              `json:"allow"`
+	Index    []string          `json:"index"`
+	Expires  string            `json:"expires"`
+	Headers  map[string]string `json:"headers"`
+}
+
+// Web describes the web server configuration declared by an application.
+type Web struct {
+	Locations map[string]Location `json:"locations"`
+	Commands  struct {
+		Start string `json:"start"`
+	} `json:"commands"`
+}
+
+// Worker describes a single worker instance declared by an application.
+type Worker struct {
+	Commands struct {
+		Start string `json:"start"`
+	} `json:"commands"`
+	Mounts        map[string]Mount  `json:"mounts"`
+	Relationships map[string]string `json:"relationships"`
+}
+
+// ApplicationInfo describes the application configuration decoded from
+// PLATFORM_APPLICATION. The schema is open-ended, so any key not modeled
+// above is still available, undecoded, via Raw.
+type ApplicationInfo struct {
+	Name          string                       `json:"name"`
+	Type          string                       `json:"type"`
+	Size          string                       `json:"size"`
+	Disk          int                          `json:"disk"`
+	Mounts        map[string]Mount             `json:"mounts"`
+	Relationships map[string]string            `json:"relationships"`
+	Dependencies  map[string]json.RawMessage   `json:"dependencies"`
+	Hooks         Hooks                        `json:"hooks"`
+	Crons         map[string]Cron              `json:"crons"`
+	Web           Web                          `json:"web"`
+	Workers       map[string]Worker            `json:"workers"`
+	Variables     map[string]map[string]string `json:"variables"`
+	Raw           map[string]json.RawMessage   `json:"-"`
+}
+
+// UnmarshalJSON decodes the modeled fields as usual, then decodes the same
+// payload a second time into Raw so callers can drill into keys we haven't
+// modeled yet.
+func (a *ApplicationInfo) UnmarshalJSON(data []byte) error {
+	type applicationInfoAlias ApplicationInfo
+
+	aux := &struct{ *applicationInfoAlias }{applicationInfoAlias: (*applicationInfoAlias)(a)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &a.Raw)
+}
+
 type PlatformInfo struct {
 	Relationships Relationships
-	//Application     ApplicationInfo
 	//Routes          RouteInfo
 	//Variables       map[string]string
 	ApplicationName string
@@ -68,52 +273,64 @@ type PlatformConfig struct {
 	mode         string
 
 	// Prefixed complex values.
-	relationships Relationships
-	//Application     ApplicationInfo
-	//Routes          RouteInfo
-	variables envList
+	relationshipsRaw string
+	applicationRaw   string
+	routesRaw        string
+	variables        envList
 
-	// Unprefixed simple values.
-	socket string
-	port   string
+	// Unprefixed simple values. These are pointers rather than plain strings
+	// so ListenSpec can tell "unset" apart from "set to an empty string".
+	socket *string
+	port   *string
 
 	// Internal data.
-	prefix string
+	prefix     string
+	provider   string
+	formatters map[string]CredentialFormatter
 }
 
+// CredentialFormatter turns a single relationship instance into whatever
+// shape a particular client library expects, e.g. a DSN string for
+// database/sql or a struct for a driver-specific config type.
+type CredentialFormatter func(Relationship) (interface{}, error)
+
 func NewConfigReal(getter envReader, prefix string) (*PlatformConfig, error) {
 	p := &PlatformConfig{}
 
 	p.prefix = prefix
+	p.provider = providerForPrefix(prefix)
 
 	// If it's not a valid platform, bail out now.
-	if getter(prefix+"APPLICATION_NAME") == "" {
+	if lookupOrEmpty(getter, prefix+"APPLICATION_NAME") == "" {
 		return nil, notValidPlatform
 	}
 
 	// Extract the easy environment variables.
-	p.applicationName = getter(p.prefix + "APPLICATION_NAME")
-	p.appDir = getter(p.prefix + "APP_DIR")
-	p.documentRoot = getter(p.prefix + "DOCUMENT_ROOT")
-	p.treeId = getter(p.prefix + "TREE_ID")
-	p.branch = getter(p.prefix + "BRANCH")
-	p.environment = getter(p.prefix + "ENVIRONMENT")
-	p.project = getter(p.prefix + "PROJECT")
-	p.entropy = getter(p.prefix + "PROJECT_ENTROPY")
-	p.smtpHost = getter(p.prefix + "SMTP_HOST")
-	p.mode = getter(p.prefix + "MODE")
-	p.socket = getter("SOCKET")
-	p.port = getter("PORT")
+	p.applicationName = lookupOrEmpty(getter, p.prefix+"APPLICATION_NAME")
+	p.appDir = lookupOrEmpty(getter, p.prefix+"APP_DIR")
+	p.documentRoot = lookupOrEmpty(getter, p.prefix+"DOCUMENT_ROOT")
+	p.treeId = lookupOrEmpty(getter, p.prefix+"TREE_ID")
+	p.branch = lookupOrEmpty(getter, p.prefix+"BRANCH")
+	p.environment = lookupOrEmpty(getter, p.prefix+"ENVIRONMENT")
+	p.project = lookupOrEmpty(getter, p.prefix+"PROJECT")
+	p.entropy = lookupOrEmpty(getter, p.prefix+"PROJECT_ENTROPY")
+	p.smtpHost = lookupOrEmpty(getter, p.prefix+"SMTP_HOST")
+	p.mode = lookupOrEmpty(getter, p.prefix+"MODE")
+
+	// SOCKET and PORT are unprefixed and only one of the two is normally
+	// set; keep the unset/empty distinction so ListenSpec can fall back
+	// correctly instead of treating an unset PORT as "listen on port \"\"".
+	if val, ok := getter("SOCKET"); ok {
+		p.socket = &val
+	}
+	if val, ok := getter("PORT"); ok {
+		p.port = &val
+	}
 
-	// Extract the complex environment variables (serialized JSON strings).
-	// @todo Rename this to credentials, at least externally.
-	/*
-		rels, err := getPlatformshRelationships()
-		if err != nil {
-			return nil, err
-		}
-		p.relationships = rels
-	*/
+	// Like routes, relationships are decoded lazily by Relationships() since
+	// they're not available at build time.
+	p.relationshipsRaw = lookupOrEmpty(getter, p.prefix+"RELATIONSHIPS")
+	p.formatters = defaultFormatters()
 
 	// Extract the PLATFORM_VARIABLES array.
 	vars, err := extractVariables(getter, prefix)
@@ -122,15 +339,50 @@ func NewConfigReal(getter envReader, prefix string) (*PlatformConfig, error) {
 	}
 	p.variables = vars
 
-	// @todo extract PLATFORM_ROUTES
+	// The routes themselves are decoded lazily by Routes(), since they're
+	// not available at build time and there's no point paying the decode
+	// cost for callers who never ask for them.
+	p.routesRaw = lookupOrEmpty(getter, p.prefix+"ROUTES")
 
-	// @todo extract PLATFORM_APPLICATION (oh dear oh dear)
+	// Like routes and relationships, the application definition is decoded
+	// lazily by Application().
+	p.applicationRaw = lookupOrEmpty(getter, p.prefix+"APPLICATION")
 
 	return p, nil
 }
 
 func NewConfig() (*PlatformConfig, error) {
-	return NewConfigReal(os.Getenv, "PLATFORM_")
+	return NewConfigReal(os.LookupEnv, "PLATFORM_")
+}
+
+// NewConfigWithPrefixes probes the given env var prefixes in order and
+// builds a PlatformConfig from the first one whose "<PREFIX>APPLICATION_NAME"
+// is set. It's useful for apps that may run on more than one provider, or on
+// a provider whose prefix varies by deployment.
+func NewConfigWithPrefixes(getter envReader, prefixes ...string) (*PlatformConfig, error) {
+	for _, prefix := range prefixes {
+		if lookupOrEmpty(getter, prefix+"APPLICATION_NAME") == "" {
+			continue
+		}
+		return NewConfigReal(getter, prefix)
+	}
+
+	return nil, notValidPlatform
+}
+
+// NewConfigAuto builds a PlatformConfig by probing the known Platform.sh and
+// Upsun env var prefixes, in that order.
+func NewConfigAuto() (*PlatformConfig, error) {
+	return NewConfigWithPrefixes(os.LookupEnv, "PLATFORM_", "UPSUN_")
+}
+
+// providerForPrefix reports which hosting provider conventionally uses the
+// given env var prefix.
+func providerForPrefix(prefix string) string {
+	if prefix == "UPSUN_" {
+		return ProviderUpsun
+	}
+	return ProviderPlatformsh
 }
 
 func (p *PlatformConfig) InBuild() bool {
@@ -141,19 +393,36 @@ func (p *PlatformConfig) OnEnterprise() bool {
 	return p.mode == "enterprise"
 }
 
+// Provider reports which hosting provider ("platformsh" or "upsun") this
+// configuration was read from.
+func (p *PlatformConfig) Provider() string {
+	return p.provider
+}
+
+// OnUpsun reports whether the application is running on Upsun, as opposed
+// to Platform.sh.
+func (p *PlatformConfig) OnUpsun() bool {
+	return p.provider == ProviderUpsun
+}
+
 func (p *PlatformConfig) OnProduction() bool {
 	if p.InBuild() {
 		return false
 	}
 
-	var prodBranch string
+	return p.branch == p.productionBranch()
+}
+
+// productionBranch reports the branch name that this provider treats as
+// production, so OnProduction() can be overridden per-provider.
+func (p *PlatformConfig) productionBranch() string {
 	if p.OnEnterprise() {
-		prodBranch = "production"
-	} else {
-		prodBranch = "master"
+		return "production"
 	}
-
-	return p.branch == prodBranch
+	if p.OnUpsun() {
+		return "main"
+	}
+	return "master"
 }
 
 func (p *PlatformConfig) ApplicationName() string {
@@ -193,24 +462,414 @@ func (p *PlatformConfig) SmtpHost() string {
 }
 
 func (p *PlatformConfig) Port() string {
-	return p.port
+	if p.port == nil {
+		return ""
+	}
+	return *p.port
 }
 
 func (p *PlatformConfig) Socket() string {
-	return p.socket
+	if p.socket == nil {
+		return ""
+	}
+	return *p.socket
+}
+
+// ListenSpec reports the network and address this application should listen
+// on. Platform.sh normally sets only one of SOCKET or PORT depending on how
+// the app's web upstream is configured; a unix socket is preferred over a
+// TCP port when both happen to be set. It returns an error if neither is
+// set, which would otherwise be indistinguishable from "listen on port \"\"".
+func (p *PlatformConfig) ListenSpec() (network string, address string, err error) {
+	if p.socket != nil {
+		return "unix", *p.socket, nil
+	}
+	if p.port != nil {
+		return "tcp", ":" + *p.port, nil
+	}
+	return "", "", fmt.Errorf("neither SOCKET nor PORT is set")
 }
 
 func (p *PlatformConfig) Variable(name string, defaultValue string) string {
-	if val, ok := p.variables[name]; ok {
+	if val, ok := p.variables.get(name); ok {
 		return val
 	}
 	return defaultValue
 }
 
+// VariableBool reads a variable and parses it as a bool, falling back to
+// defaultValue if it's unset or unparseable.
+func (p *PlatformConfig) VariableBool(name string, defaultValue bool) bool {
+	val, ok := p.variables.get(name)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(val)
+	if err != nil {
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// VariableInt reads a variable and parses it as an int, falling back to
+// defaultValue if it's unset or unparseable.
+func (p *PlatformConfig) VariableInt(name string, defaultValue int) int {
+	val, ok := p.variables.get(name)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(val)
+	if err != nil {
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// VariableFloat64 reads a variable and parses it as a float64, falling back
+// to defaultValue if it's unset or unparseable.
+func (p *PlatformConfig) VariableFloat64(name string, defaultValue float64) float64 {
+	val, ok := p.variables.get(name)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// VariableJSON reads a variable and unmarshals it as JSON into out.
+func (p *PlatformConfig) VariableJSON(name string, out interface{}) error {
+	val, ok := p.variables.get(name)
+	if !ok {
+		return fmt.Errorf("No such variable defined: %s.", name)
+	}
+
+	return json.Unmarshal([]byte(val), out)
+}
+
+// VariablesPrefixed returns all variables whose name starts with the given
+// prefix (e.g. "env:"), keyed by name with the prefix stripped. This is the
+// idiomatic way to read a Platform.sh variable namespace.
+func (p *PlatformConfig) VariablesPrefixed(prefix string) map[string]string {
+	result := make(map[string]string)
+
+	for _, v := range p.variables {
+		if strings.HasPrefix(v.Name, prefix) {
+			result[strings.TrimPrefix(v.Name, prefix)] = v.Value
+		}
+	}
+
+	return result
+}
+
 func (p *PlatformConfig) Variables() envList {
 	return p.variables
 }
 
+// Relationships returns the full set of relationships (credentials for
+// databases and other services) defined for the current environment. It
+// returns ErrNotInRuntime if called during the build phase.
+func (p *PlatformConfig) Relationships() (Relationships, error) {
+	if p.InBuild() {
+		return nil, ErrNotInRuntime
+	}
+
+	return decodeRelationships(p.relationshipsRaw)
+}
+
+// RegisterFormatter registers a credential formatter under the given name,
+// overriding any built-in formatter already registered under that name.
+// Call it before querying FormattedCredentials or SqlDsn.
+func (p *PlatformConfig) RegisterFormatter(name string, fn CredentialFormatter) {
+	p.formatters[name] = fn
+}
+
+// FormattedCredentials looks up the first instance of the named
+// relationship and runs it through the named formatter.
+func (p *PlatformConfig) FormattedCredentials(relationship string, formatter string) (interface{}, error) {
+	instance, err := p.relationshipInstance(relationship)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, ok := p.formatters[formatter]
+	if !ok {
+		return nil, fmt.Errorf("No such credential formatter registered: %s.", formatter)
+	}
+
+	return fn(instance)
+}
+
+// SqlDsn produces an SQL (or other database) connection string for the
+// named relationship, choosing the formatter automatically based on the
+// relationship's scheme (e.g. "mysql" or "pgsql").
+func (p *PlatformConfig) SqlDsn(relationship string) (string, error) {
+	instance, err := p.relationshipInstance(relationship)
+	if err != nil {
+		return "", err
+	}
+
+	fn, ok := p.formatters[instance.Scheme]
+	if !ok {
+		return "", fmt.Errorf("No credential formatter registered for scheme: %s.", instance.Scheme)
+	}
+
+	formatted, err := fn(instance)
+	if err != nil {
+		return "", err
+	}
+
+	dsn, ok := formatted.(string)
+	if !ok {
+		return "", fmt.Errorf("Formatter for scheme %s did not return a DSN string.", instance.Scheme)
+	}
+
+	return dsn, nil
+}
+
+// relationshipInstance returns the first instance of the named relationship.
+func (p *PlatformConfig) relationshipInstance(relationship string) (Relationship, error) {
+	rels, err := p.Relationships()
+	if err != nil {
+		return Relationship{}, err
+	}
+
+	instances, ok := rels[relationship]
+	if !ok || len(instances) == 0 {
+		return Relationship{}, fmt.Errorf("No such relationship defined: %s.", relationship)
+	}
+
+	return instances[0], nil
+}
+
+// decodeRelationships decodes the base64-encoded JSON payload of
+// PLATFORM_RELATIONSHIPS.
+func decodeRelationships(raw string) (Relationships, error) {
+	var rels Relationships
+	if err := decodeBase64JSON(raw, &rels); err != nil {
+		return nil, err
+	}
+
+	return rels, nil
+}
+
+// defaultFormatters returns a fresh map of the built-in credential
+// formatters, keyed both by their conventional formatter name and by the
+// relationship scheme Platform.sh actually reports for that service (which
+// isn't always the service name itself, e.g. RabbitMQ relationships report
+// scheme "amqp").
+func defaultFormatters() map[string]CredentialFormatter {
+	return map[string]CredentialFormatter{
+		"pdo_mysql":         formatMysqlDsn,
+		"mysql":             formatMysqlDsn,
+		"pdo_pgsql":         formatPgsqlDsn,
+		"pgsql":             formatPgsqlDsn,
+		"postgresql":        formatPgsqlDsn,
+		"mongodb_dsn":       formatMongoDsn,
+		"mongodb":           formatMongoDsn,
+		"redis_dsn":         formatRedisDsn,
+		"redis":             formatRedisDsn,
+		"amqp_dsn":          formatAmqpDsn,
+		"rabbitmq":          formatAmqpDsn,
+		"amqp":              formatAmqpDsn,
+		"elasticsearch_url": formatElasticsearchUrl,
+		"elasticsearch":     formatElasticsearchUrl,
+	}
+}
+
+func formatMysqlDsn(r Relationship) (interface{}, error) {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8", r.Username, r.Password, r.Host, r.Port, r.Path), nil
+}
+
+func formatPgsqlDsn(r Relationship) (interface{}, error) {
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s", r.Username, r.Password, r.Host, r.Port, r.Path), nil
+}
+
+func formatMongoDsn(r Relationship) (interface{}, error) {
+	return fmt.Sprintf("mongodb://%s:%s@%s:%d/%s", r.Username, r.Password, r.Host, r.Port, r.Path), nil
+}
+
+func formatRedisDsn(r Relationship) (interface{}, error) {
+	return fmt.Sprintf("redis://%s:%d", r.Host, r.Port), nil
+}
+
+func formatAmqpDsn(r Relationship) (interface{}, error) {
+	return fmt.Sprintf("amqp://%s:%s@%s:%d/", r.Username, r.Password, r.Host, r.Port), nil
+}
+
+func formatElasticsearchUrl(r Relationship) (interface{}, error) {
+	return fmt.Sprintf("http://%s:%d/%s", r.Host, r.Port, r.Path), nil
+}
+
+// Application returns the application configuration decoded from
+// PLATFORM_APPLICATION. Unlike Routes and Relationships this is available
+// during the build phase as well as at runtime.
+func (p *PlatformConfig) Application() (ApplicationInfo, error) {
+	return decodeApplication(p.applicationRaw)
+}
+
+// Mount returns the mount definition for the given path, and whether it was
+// found.
+func (p *PlatformConfig) Mount(path string) (Mount, bool) {
+	app, err := p.Application()
+	if err != nil {
+		return Mount{}, false
+	}
+
+	mount, ok := app.Mounts[path]
+	return mount, ok
+}
+
+// Worker returns the worker definition for the given name, and whether it
+// was found.
+func (p *PlatformConfig) Worker(name string) (Worker, bool) {
+	app, err := p.Application()
+	if err != nil {
+		return Worker{}, false
+	}
+
+	worker, ok := app.Workers[name]
+	return worker, ok
+}
+
+// decodeApplication decodes the base64-encoded JSON payload of
+// PLATFORM_APPLICATION.
+func decodeApplication(raw string) (ApplicationInfo, error) {
+	var app ApplicationInfo
+	if err := decodeBase64JSON(raw, &app); err != nil {
+		return ApplicationInfo{}, err
+	}
+
+	return app, nil
+}
+
+// Routes returns the full routes configuration for the current environment,
+// as defined in PLATFORM_ROUTES. It returns ErrNotInRuntime if called during
+// the build phase, when routes are not yet known.
+func (p *PlatformConfig) Routes() (Routes, error) {
+	if p.InBuild() {
+		return nil, ErrNotInRuntime
+	}
+
+	routes, err := decodeRoutes(p.routesRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	return substituteRouteTemplates(routes, p.project, p.branch), nil
+}
+
+// Route returns the route definition for the given URL, and whether it was
+// found.
+func (p *PlatformConfig) Route(url string) (Route, bool) {
+	routes, err := p.Routes()
+	if err != nil {
+		return Route{}, false
+	}
+
+	route, ok := routes[url]
+	return route, ok
+}
+
+// PrimaryRoute returns the route marked as primary for the current
+// environment, and whether one was found.
+func (p *PlatformConfig) PrimaryRoute() (Route, bool) {
+	routes, err := p.Routes()
+	if err != nil {
+		return Route{}, false
+	}
+
+	for _, route := range routes {
+		if route.Primary {
+			return route, true
+		}
+	}
+
+	return Route{}, false
+}
+
+// UpstreamRoutes returns all routes of type "upstream" that point at the
+// given application name. PLATFORM_ROUTES encodes the upstream as
+// "<app-name>:<socket>" (e.g. "app:http"), so this matches on the app-name
+// portion rather than the full upstream string.
+func (p *PlatformConfig) UpstreamRoutes(appName string) map[string]Route {
+	upstreams := make(map[string]Route)
+
+	routes, err := p.Routes()
+	if err != nil {
+		return upstreams
+	}
+
+	for url, route := range routes {
+		if route.Type != "upstream" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(route.Upstream, ":")
+		if name == appName {
+			upstreams[url] = route
+		}
+	}
+
+	return upstreams
+}
+
+// decodeRoutes decodes the base64-encoded JSON payload of PLATFORM_ROUTES.
+func decodeRoutes(raw string) (Routes, error) {
+	var routes Routes
+	if err := decodeBase64JSON(raw, &routes); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// decodeBase64JSON decodes a base64-encoded JSON payload as found in most
+// Platform.sh environment variables, wrapping failures in a sentinel error
+// so callers can distinguish a decode failure from a missing variable.
+func decodeBase64JSON(raw string, out interface{}) error {
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBase64Decode, err)
+	}
+
+	if err := json.Unmarshal(decoded, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrJSONDecode, err)
+	}
+
+	return nil
+}
+
+// substituteRouteTemplates replaces the "{default}" and "{all}" placeholders
+// found in route URLs, production URLs, redirect targets, and upstream/id
+// fields with concrete values derived from the current project and branch.
+func substituteRouteTemplates(routes Routes, project string, branch string) Routes {
+	replacer := strings.NewReplacer(
+		"{default}", fmt.Sprintf("%s-%s", branch, project),
+		"{all}", "*."+project,
+	)
+
+	resolved := make(Routes, len(routes))
+	for url, route := range routes {
+		route.OriginalUrl = url
+		route.ProductionUrl = replacer.Replace(route.ProductionUrl)
+		route.To = replacer.Replace(route.To)
+		route.Id = replacer.Replace(route.Id)
+		route.Upstream = replacer.Replace(route.Upstream)
+		resolved[replacer.Replace(url)] = route
+	}
+
+	return resolved
+}
+
 // NewPlatformInfo returns a struct containing environment information
 // for the current Platform.sh environment. That includes the port on
 // which to listen for web requests, database credentials, and so on.
@@ -257,15 +916,20 @@ func (p *PlatformInfo) SqlDsn(name string) (string, error) {
 	return "", fmt.Errorf("No such relationship defined: %s.", name)
 }
 
-func getPlatformshRelationships() (Relationships, error) {
+// Application returns the application configuration decoded from
+// PLATFORM_APPLICATION.
+func (p *PlatformInfo) Application() (ApplicationInfo, error) {
+	return decodeApplication(os.Getenv("PLATFORM_APPLICATION"))
+}
 
-	relationships := os.Getenv("PLATFORM_RELATIONSHIPS")
-	jsonRelationships, _ := base64.StdEncoding.DecodeString(relationships)
+func getPlatformshRelationships() (Relationships, error) {
+	raw, ok := os.LookupEnv("PLATFORM_RELATIONSHIPS")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrVarNotSet, "PLATFORM_RELATIONSHIPS")
+	}
 
 	var rels Relationships
-
-	err := json.Unmarshal([]byte(jsonRelationships), &rels)
-	if err != nil {
+	if err := decodeBase64JSON(raw, &rels); err != nil {
 		return nil, err
 	}
 
@@ -273,15 +937,15 @@ func getPlatformshRelationships() (Relationships, error) {
 }
 
 func extractVariables(getter envReader, prefix string) (envList, error) {
+	name := prefix + "VARIABLES"
 
-	vars := getter(prefix + "VARIABLES")
-
-	jsonVars, _ := base64.StdEncoding.DecodeString(vars)
+	raw, ok := getter(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrVarNotSet, name)
+	}
 
 	var env envList
-
-	err := json.Unmarshal([]byte(jsonVars), &env)
-	if err != nil {
+	if err := decodeBase64JSON(raw, &env); err != nil {
 		return nil, err
 	}
 